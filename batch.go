@@ -0,0 +1,295 @@
+package paddleocr
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// BatchOptions configures OcrDir.
+type BatchOptions struct {
+	// IncludeGlob is matched against each file's base name; empty matches
+	// every file. Uses filepath.Match syntax.
+	IncludeGlob string
+	// ExcludeGlob skips files that would otherwise be included. Uses
+	// filepath.Match syntax.
+	ExcludeGlob string
+	// Recursive walks subdirectories of root when true.
+	Recursive bool
+	// Concurrency is the number of images OCR'd in parallel, and the size of
+	// the PpocrPool OcrDir starts internally. Defaults to 1.
+	Concurrency int
+	// ManifestPath, if set, records completed paths (one per line) so a
+	// re-run skips finished work. Essential when OCR-ing thousands of pages
+	// against a subprocess that periodically restarts.
+	ManifestPath string
+}
+
+// BatchResult is streamed on the channel returned by OcrDir for every file
+// that was (or was attempted to be) OCR'd.
+type BatchResult struct {
+	Path   string `json:"path"`
+	Result Result `json:"result"`
+	// Error is Err.Error(), included so WriteJSONL actually records the
+	// failure: the error interface itself has no json tag/marshaler and
+	// would otherwise encode as an empty object.
+	Error string `json:"error,omitempty"`
+	Err   error  `json:"-"`
+}
+
+// newBatchResult builds a BatchResult, deriving Error from err so JSON
+// encoding (WriteJSONL) and Go callers (r.Err) both see the same failure.
+func newBatchResult(path string, result Result, err error) BatchResult {
+	r := BatchResult{Path: path, Result: result, Err: err}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	return r
+}
+
+// OcrDir walks root (respecting opts.IncludeGlob, opts.ExcludeGlob and
+// opts.Recursive), OCRs every matching file through a pool of
+// opts.Concurrency workers sharing p's executable and OcrArgs, and streams a
+// BatchResult per file on the returned channel so callers can render
+// progress as it arrives.
+//
+// The channel is closed once every matching, not-yet-completed file has
+// been processed.
+func (p *Ppocr) OcrDir(root string, opts BatchOptions) (<-chan BatchResult, error) {
+	return ocrDir(p.exePath, p.args, root, opts)
+}
+
+// OcrDir is a package-level equivalent of (*Ppocr).OcrDir that does not
+// require an existing Ppocr instance.
+func OcrDir(exePath string, args OcrArgs, root string, opts BatchOptions) (<-chan BatchResult, error) {
+	return ocrDir(exePath, args, root, opts)
+}
+
+func ocrDir(exePath string, args OcrArgs, root string, opts BatchOptions) (<-chan BatchResult, error) {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	paths, err := walkBatchDir(root, opts)
+	if err != nil {
+		return nil, err
+	}
+	done, err := loadManifest(opts.ManifestPath)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := NewPpocrPool(exePath, args, opts.Concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	resultChan := make(chan BatchResult)
+	go func() {
+		defer close(resultChan)
+		defer pool.Close()
+
+		var manifest *os.File
+		if opts.ManifestPath != "" {
+			if f, err := os.OpenFile(opts.ManifestPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644); err == nil {
+				manifest = f
+				defer f.Close()
+			}
+		}
+		var manifestMu sync.Mutex
+
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, opts.Concurrency)
+		for _, path := range paths {
+			if done[path] {
+				continue
+			}
+			path := path
+			sem <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				result, err := pool.OcrFileAndParse(path)
+				resultChan <- newBatchResult(path, result, err)
+				if err == nil && manifest != nil {
+					manifestMu.Lock()
+					fmt.Fprintln(manifest, path)
+					manifestMu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+	}()
+	return resultChan, nil
+}
+
+func walkBatchDir(root string, opts BatchOptions) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && !opts.Recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		name := filepath.Base(path)
+		if opts.IncludeGlob != "" {
+			ok, err := filepath.Match(opts.IncludeGlob, name)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return nil
+			}
+		}
+		if opts.ExcludeGlob != "" {
+			ok, err := filepath.Match(opts.ExcludeGlob, name)
+			if err != nil {
+				return err
+			}
+			if ok {
+				return nil
+			}
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// loadManifest reads a manifest of previously completed paths, returning an
+// empty set if manifestPath is unset or does not exist yet.
+func loadManifest(manifestPath string) (map[string]bool, error) {
+	done := make(map[string]bool)
+	if manifestPath == "" {
+		return done, nil
+	}
+	f, err := os.Open(manifestPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return done, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			done[line] = true
+		}
+	}
+	return done, scanner.Err()
+}
+
+// WriteJSONL drains results, writing one JSON-encoded BatchResult per line
+// to w. It keeps draining even after the first error so the producing
+// goroutine never blocks trying to send, and returns the first error seen.
+func WriteJSONL(w io.Writer, results <-chan BatchResult) error {
+	enc := json.NewEncoder(w)
+	var firstErr error
+	for r := range results {
+		if r.Err != nil && firstErr == nil {
+			firstErr = r.Err
+		}
+		if err := enc.Encode(r); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// WriteCSV drains results, writing one row per recognized text box (path,
+// box, score, text) to w. A successfully processed file with zero text
+// boxes still gets one row (with empty box/score/text) so it can be told
+// apart from a path that was never attempted.
+func WriteCSV(w io.Writer, results <-chan BatchResult) error {
+	cw := csv.NewWriter(w)
+	defer cw.Flush()
+	if err := cw.Write([]string{"path", "box", "score", "text"}); err != nil {
+		return err
+	}
+	var firstErr error
+	for r := range results {
+		if r.Err != nil {
+			if firstErr == nil {
+				firstErr = r.Err
+			}
+			continue
+		}
+		if len(r.Result.Data) == 0 {
+			if err := cw.Write([]string{r.Path, "", "", ""}); err != nil && firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		for _, d := range r.Result.Data {
+			box, err := json.Marshal(d.Rect)
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			row := []string{r.Path, string(box), fmt.Sprintf("%v", d.Score), d.Text}
+			if err := cw.Write(row); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// WriteHOCR drains results, writing a minimal hOCR document (one ocr_page
+// per file, one ocrx_word span per recognized text box) to w, so results can
+// be consumed by hOCR-aware indexing/search pipelines.
+func WriteHOCR(w io.Writer, results <-chan BatchResult) error {
+	fmt.Fprintln(w, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintln(w, `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">`)
+	fmt.Fprintln(w, `<html xmlns="http://www.w3.org/1999/xhtml">`)
+	fmt.Fprintln(w, `<head><meta http-equiv="Content-Type" content="text/html; charset=utf-8" /><meta name="ocr-system" content="paddleocr" /></head>`)
+	fmt.Fprintln(w, `<body>`)
+
+	var firstErr error
+	for r := range results {
+		if r.Err != nil {
+			if firstErr == nil {
+				firstErr = r.Err
+			}
+			continue
+		}
+		fmt.Fprintf(w, "<div class=\"ocr_page\" title=\"image %s\">\n", html.EscapeString(r.Path))
+		for i, d := range r.Result.Data {
+			fmt.Fprintf(w, "<span class=\"ocrx_word\" id=\"word_%d\" title=\"bbox %s; x_wconf %d\">%s</span>\n",
+				i, hocrBBox(d.Rect), int(d.Score*100), html.EscapeString(d.Text))
+		}
+		fmt.Fprintln(w, `</div>`)
+	}
+	fmt.Fprintln(w, `</body></html>`)
+	return firstErr
+}
+
+// hocrBBox reduces a PaddleOCR-json quadrilateral (4 [x,y] points) to the
+// axis-aligned bounding box hOCR expects.
+func hocrBBox(rect [][]int) string {
+	if len(rect) == 0 {
+		return "0 0 0 0"
+	}
+	minX, minY := rect[0][0], rect[0][1]
+	maxX, maxY := rect[0][0], rect[0][1]
+	for _, p := range rect[1:] {
+		minX, maxX = minInt(minX, p[0]), maxInt(maxX, p[0])
+		minY, maxY = minInt(minY, p[1]), maxInt(maxY, p[1])
+	}
+	return fmt.Sprintf("%d %d %d %d", minX, minY, maxX, maxY)
+}
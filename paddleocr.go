@@ -1,7 +1,9 @@
 package paddleocr
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,6 +14,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -33,6 +36,12 @@ type OcrArgs struct {
 	// 例如：
 	//   paddleocr.OcrArgs{ ConfigPath: paddleocr.ConfigChinese }
 	ConfigPath string `paddleocr:"config_path"`
+
+	// StderrHandler, if set, is invoked with every chunk the OCR child
+	// process writes to stderr, so callers can log or forward diagnostics
+	// that would otherwise only be visible via Ppocr.Stderr. It is not a
+	// PaddleOCR-json command-line flag and is not part of CmdString.
+	StderrHandler func([]byte)
 }
 
 const paddleocrTag = "paddleocr"
@@ -50,6 +59,95 @@ const (
 
 const clipboardImagePath = `clipboard`
 
+// DefaultMaxResponseBytes is the default value of Ppocr.MaxResponseBytes.
+const DefaultMaxResponseBytes = 32 * 1024 * 1024
+
+// maxStderrBufferBytes bounds how much of the child's stderr output
+// stderrRingBuffer (and therefore Ppocr.Stderr and OcrTimeoutError) retains.
+const maxStderrBufferBytes = 64 * 1024
+
+// OcrTimeoutError is returned by an OCR call that exceeds Ppocr.ReadTimeout.
+// By the time it is returned, the worker has already been killed and
+// restarted via the same mechanism as restartTimer, so the Ppocr instance
+// remains usable for the next call.
+type OcrTimeoutError struct {
+	Timeout time.Duration
+	// Stderr holds the last bytes of the child's stderr output at the time
+	// of the timeout, which often explains why it stopped responding.
+	Stderr string
+}
+
+func (e *OcrTimeoutError) Error() string {
+	if e.Stderr == "" {
+		return fmt.Sprintf("paddleocr: OCR call timed out after %s", e.Timeout)
+	}
+	return fmt.Sprintf("paddleocr: OCR call timed out after %s, stderr: %s", e.Timeout, e.Stderr)
+}
+
+// stderrRingBuffer keeps only the last maxStderrBufferBytes written to it,
+// so a chatty or crashing child process can't grow its diagnostics buffer
+// without bound.
+type stderrRingBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (b *stderrRingBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > maxStderrBufferBytes {
+		b.buf = b.buf[len(b.buf)-maxStderrBufferBytes:]
+	}
+	return len(p), nil
+}
+
+func (b *stderrRingBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]byte, len(b.buf))
+	copy(out, b.buf)
+	return out
+}
+
+// stderrWriter tees the child's stderr into the bounded ring buffer used for
+// error messages and, if set, into OcrArgs.StderrHandler.
+type stderrWriter struct {
+	ring    *stderrRingBuffer
+	handler func([]byte)
+}
+
+func (w *stderrWriter) Write(p []byte) (int, error) {
+	n, err := w.ring.Write(p)
+	if w.handler != nil {
+		chunk := make([]byte, len(p))
+		copy(chunk, p)
+		w.handler(chunk)
+	}
+	return n, err
+}
+
+// readFramedResponse reads one newline-terminated frame from r, using
+// bufio's ReadSlice so the accumulated line is bounded by maxBytes instead
+// of growing without limit when a stray byte sequence or a stalled child
+// never produces the terminating '\n'.
+func readFramedResponse(r *bufio.Reader, maxBytes int64) ([]byte, error) {
+	var buf []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		buf = append(buf, chunk...)
+		if int64(len(buf)) > maxBytes {
+			return nil, fmt.Errorf("paddleocr: OCR response exceeded MaxResponseBytes (%d)", maxBytes)
+		}
+		if err == nil {
+			return buf, nil
+		}
+		if err != bufio.ErrBufferFull {
+			return buf, err
+		}
+	}
+}
+
 func (o OcrArgs) CmdString() string {
 	var s string
 	v := reflect.ValueOf(o)
@@ -112,10 +210,26 @@ type Ppocr struct {
 	ppLock          *sync.Mutex
 	restartExitChan chan struct{}
 	internalErr     error
-
-	cmdStdout io.ReadCloser
-	cmdStdin  io.WriteCloser
-	cmd       *exec.Cmd
+	// restartCount counts every restart() call, regardless of whether it was
+	// triggered by restartTimer, a timed-out OCR call, or a pool noticing a
+	// previously failed worker. Read it with RestartCount.
+	restartCount int64
+
+	// MaxResponseBytes caps how large a single OCR response frame may grow
+	// before an OCR call gives up and returns an error, guarding against a
+	// corrupted child that never emits the terminating newline. Zero means
+	// DefaultMaxResponseBytes.
+	MaxResponseBytes int64
+	// ReadTimeout, if non-zero, bounds how long a single OCR call waits for
+	// the child's response. On timeout the call returns an *OcrTimeoutError
+	// and the worker is restarted via the same mechanism as restartTimer.
+	ReadTimeout time.Duration
+
+	cmdStdout    io.ReadCloser
+	stdoutReader *bufio.Reader
+	cmdStdin     io.WriteCloser
+	cmd          *exec.Cmd
+	stderrBuf    *stderrRingBuffer
 	// 无缓冲同步信号通道，close()中接收，Run()中发送。
 	// Run()退出必须有对应close方法的调用
 	runGoroutineExitedChan chan struct{}
@@ -138,6 +252,8 @@ func NewPpocr(exePath string, args OcrArgs) (*Ppocr, error) {
 		ppLock:                 new(sync.Mutex),
 		restartExitChan:        make(chan struct{}),
 		runGoroutineExitedChan: make(chan struct{}),
+		stderrBuf:              new(stderrRingBuffer),
+		MaxResponseBytes:       DefaultMaxResponseBytes,
 	}
 
 	p.ppLock.Lock()
@@ -175,9 +291,12 @@ func (p *Ppocr) initPpocr(exePath string, args OcrArgs) error {
 	}
 	p.cmdStdin = wc
 	p.cmdStdout = rc
+	p.stdoutReader = bufio.NewReaderSize(rc, 4096)
 
-	var stderrBuffer bytes.Buffer
-	p.cmd.Stderr = &stderrBuffer
+	if p.stderrBuf == nil {
+		p.stderrBuf = new(stderrRingBuffer)
+	}
+	p.cmd.Stderr = &stderrWriter{ring: p.stderrBuf, handler: args.StderrHandler}
 
 	err = p.cmd.Start()
 	if err != nil {
@@ -194,25 +313,30 @@ func (p *Ppocr) initPpocr(exePath string, args OcrArgs) error {
 		p.runGoroutineExitedChan <- struct{}{}
 	}()
 
-	buf := make([]byte, 4096)
-	start := 0
+	// 不能假设完成标记以换行结尾（PaddleOCR-json在这之后可能直接阻塞等待
+	// stdin，而不会再输出换行），所以用原始Read而不是按行读取，在每次Read
+	// 后对累积的缓冲区做子串查找，和重写前的行为一致。
+	const maxInitBytes = 1 << 20
+	var buf []byte
+	tmp := make([]byte, 4096)
 	for {
-		n, err := rc.Read(buf[start:])
+		n, err := p.stdoutReader.Read(tmp)
+		if n > 0 {
+			buf = append(buf, tmp[:n]...)
+			if bytes.Contains(buf, []byte("OCR init completed.")) {
+				return p.internalErr
+			}
+		}
 		if err != nil {
 			if p.internalErr != nil {
-				return fmt.Errorf("OCR init failed: %v,run error: %v", err, p.internalErr)
+				return fmt.Errorf("OCR init failed: %v, run error: %v", err, p.internalErr)
 			}
-			return fmt.Errorf("OCR init failed, error: %v, output: %s %s", err, buf[:start], stderrBuffer.String())
+			return fmt.Errorf("OCR init failed: %v, stderr: %s", err, p.stderrBuf.Bytes())
 		}
-		start += n
-		if start >= len(buf) {
+		if int64(len(buf)) > maxInitBytes {
 			return fmt.Errorf("OCR init failed: output too long")
 		}
-		if bytes.Contains(buf[:start], []byte("OCR init completed.")) {
-			break
-		}
 	}
-	return p.internalErr
 }
 
 // Close cleanly shuts down the OCR process associated with the Ppocr instance.
@@ -273,8 +397,7 @@ func (p *Ppocr) restartTimer() {
 		case <-ticker.C:
 			// fmt.Println("restart OCR process")
 			p.ppLock.Lock()
-			_ = p.close()
-			p.internalErr = p.initPpocr(p.exePath, p.args)
+			p.restart()
 			p.ppLock.Unlock()
 			// fmt.Println("restart OCR process done")
 		case <-p.restartExitChan:
@@ -284,6 +407,23 @@ func (p *Ppocr) restartTimer() {
 	}
 }
 
+// restart closes and re-initializes the OCR child process, recording the
+// restart so RestartCount (and therefore PpocrPool.Metrics) reflects every
+// restart, however it was triggered: restartTimer's 20-minute memory-leak
+// timer, a ctx/ReadTimeout timeout in ocrContext, or a pool finding a
+// previously failed worker in serve. Callers must hold ppLock.
+func (p *Ppocr) restart() {
+	_ = p.close()
+	p.internalErr = p.initPpocr(p.exePath, p.args)
+	atomic.AddInt64(&p.restartCount, 1)
+}
+
+// RestartCount returns the number of times this Ppocr's child process has
+// been restarted, for any reason.
+func (p *Ppocr) RestartCount() int64 {
+	return atomic.LoadInt64(&p.restartCount)
+}
+
 type imageData struct {
 	Path       string `json:"image_path,omitempty"`
 	ContentB64 []byte `json:"image_base64,omitempty"`
@@ -305,7 +445,48 @@ func (p *Ppocr) OcrFile(imagePath string) ([]byte, error) {
 	return p.ocr(dataJson)
 }
 
+// OcrFileContext is like OcrFile but accepts a context.Context.
+//
+// If ctx is cancelled or its deadline expires before the OCR process
+// replies, the pending read on the child's stdout is unblocked, the
+// underlying process is restarted via the same mechanism as restartTimer,
+// and ctx.Err() is returned. The Ppocr instance remains usable for
+// subsequent calls.
+func (p *Ppocr) OcrFileContext(ctx context.Context, imagePath string) ([]byte, error) {
+	var data = imageData{Path: imagePath}
+	dataJson, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	p.ppLock.Lock()
+	defer p.ppLock.Unlock()
+	if p.internalErr != nil {
+		return nil, p.internalErr
+	}
+	return p.ocrContext(ctx, dataJson)
+}
+
 func (p *Ppocr) ocr(dataJson []byte) ([]byte, error) {
+	return p.ocrContext(context.Background(), dataJson)
+}
+
+type readResult struct {
+	data []byte
+	err  error
+}
+
+// 加锁调用。与ocr相同，但使用bufio.Reader按帧读取并受MaxResponseBytes限制，
+// 在ctx被取消、超时或ReadTimeout到期时不再等待子进程的阻塞读，而是通过关闭
+// 管道来唤醒读取，并按restartTimer相同的方式重启子进程，使Ppocr实例在之后
+// 仍可继续使用。
+func (p *Ppocr) ocrContext(ctx context.Context, dataJson []byte) ([]byte, error) {
+	parent := ctx
+	if p.ReadTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.ReadTimeout)
+		defer cancel()
+	}
+
 	_, err := p.cmdStdin.Write(dataJson)
 	if err != nil {
 		return nil, err
@@ -314,22 +495,39 @@ func (p *Ppocr) ocr(dataJson []byte) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	content := make([]byte, 1024*10)
-	start := 0
-	for {
-		n, err := p.cmdStdout.Read(content[start:])
-		if err != nil {
-			return nil, err
-		}
-		start += n
-		if start >= len(content) {
-			content = append(content, make([]byte, 1024*10)...)
-		}
-		if content[start-1] == '\n' {
-			break
+
+	reader := p.stdoutReader
+	maxBytes := p.MaxResponseBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxResponseBytes
+	}
+	resultChan := make(chan readResult, 1)
+	go func() {
+		data, err := readFramedResponse(reader, maxBytes)
+		resultChan <- readResult{data, err}
+	}()
+
+	select {
+	case res := <-resultChan:
+		return res.data, res.err
+	case <-ctx.Done():
+		// 取消管道上悬挂的Read，并像内存泄漏定时器一样重启子进程，
+		// 避免这次超时让Ppocr实例永久不可用。
+		stderr := p.stderrBuf.Bytes()
+		p.restart()
+		if p.ReadTimeout > 0 && ctx.Err() == context.DeadlineExceeded && parent.Err() == nil {
+			return nil, &OcrTimeoutError{Timeout: p.ReadTimeout, Stderr: string(stderr)}
 		}
+		return nil, ctx.Err()
 	}
-	return content[:start], nil
+}
+
+// Stderr returns a snapshot of the OCR child process's recent stderr output
+// (bounded to the last ~64 KiB), primarily useful for diagnosing a timeout
+// or an unexpected exit. Set OcrArgs.StderrHandler instead if every chunk
+// needs to be observed as it is written.
+func (p *Ppocr) Stderr() io.Reader {
+	return bytes.NewReader(p.stderrBuf.Bytes())
 }
 
 // Ocr processes the OCR for a given image represented as a byte slice.
@@ -349,6 +547,23 @@ func (p *Ppocr) Ocr(image []byte) ([]byte, error) {
 	return p.ocr(dataJson)
 }
 
+// OcrContext is like Ocr but accepts a context.Context. See OcrFileContext
+// for the cancellation/deadline behavior.
+func (p *Ppocr) OcrContext(ctx context.Context, image []byte) ([]byte, error) {
+	if p.internalErr != nil {
+		return nil, p.internalErr
+	}
+	var data = imageData{ContentB64: image}
+	dataJson, err := json.Marshal(data) //auto base64
+	if err != nil {
+		return nil, err
+	}
+
+	p.ppLock.Lock()
+	defer p.ppLock.Unlock()
+	return p.ocrContext(ctx, dataJson)
+}
+
 type Data struct {
 	Rect  [][]int `json:"box"`
 	Score float32 `json:"score"`
@@ -448,3 +663,31 @@ func (p *Ppocr) OcrClipboard() ([]byte, error) {
 func (p *Ppocr) OcrClipboardAndParse() (Result, error) {
 	return p.OcrFileAndParse(clipboardImagePath)
 }
+
+// OcrFileAndParseContext is like OcrFileAndParse but accepts a context.Context.
+func (p *Ppocr) OcrFileAndParseContext(ctx context.Context, imagePath string) (Result, error) {
+	b, err := p.OcrFileContext(ctx, imagePath)
+	if err != nil {
+		return Result{}, err
+	}
+	return ParseResult(b)
+}
+
+// OcrAndParseContext is like OcrAndParse but accepts a context.Context.
+func (p *Ppocr) OcrAndParseContext(ctx context.Context, image []byte) (Result, error) {
+	b, err := p.OcrContext(ctx, image)
+	if err != nil {
+		return Result{}, err
+	}
+	return ParseResult(b)
+}
+
+// OcrClipboardContext is like OcrClipboard but accepts a context.Context.
+func (p *Ppocr) OcrClipboardContext(ctx context.Context) ([]byte, error) {
+	return p.OcrFileContext(ctx, clipboardImagePath)
+}
+
+// OcrClipboardAndParseContext is like OcrClipboardAndParse but accepts a context.Context.
+func (p *Ppocr) OcrClipboardAndParseContext(ctx context.Context) (Result, error) {
+	return p.OcrFileAndParseContext(ctx, clipboardImagePath)
+}
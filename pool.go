@@ -0,0 +1,289 @@
+package paddleocr
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// poolJob describes a single OCR request waiting to be picked up by a
+// worker goroutine.
+type poolJob struct {
+	ctx        context.Context
+	imagePath  string
+	image      []byte
+	useBytes   bool
+	resultChan chan poolResult
+}
+
+type poolResult struct {
+	data []byte
+	err  error
+}
+
+// probeJob is a HealthCheck request sent directly to one worker's probeChan,
+// bypassing the pool's shared jobChan so it reaches that specific worker
+// instead of whichever one the least-busy scheduler would have picked.
+type probeJob struct {
+	ctx        context.Context
+	image      []byte
+	resultChan chan error
+}
+
+// PoolMetrics is a point-in-time snapshot of a PpocrPool's health.
+type PoolMetrics struct {
+	// QueueDepth is the number of requests currently waiting for a free worker.
+	QueueDepth int64
+	// WorkerRestarts is the number of times each worker has been restarted,
+	// indexed by worker id.
+	WorkerRestarts []int64
+	// WorkerRequests is the number of requests each worker has served,
+	// indexed by worker id.
+	WorkerRequests []int64
+}
+
+// PpocrPool manages a fixed-size group of Ppocr child processes sharing the
+// same OcrArgs and dispatches each request to whichever worker becomes free
+// first, via a shared, unbuffered job channel. This is the in-process analog
+// of a pool of RPC workers in front of a single backend: callers get
+// concurrent throughput without managing the child processes themselves.
+type PpocrPool struct {
+	exePath string
+	args    OcrArgs
+
+	jobChan    chan poolJob
+	probeChans []chan probeJob
+	closeChan  chan struct{}
+	closeOnce  sync.Once
+	wg         sync.WaitGroup
+
+	workers  []*Ppocr
+	pending  int64
+	requests []int64
+}
+
+// NewPpocrPool starts size Ppocr workers, all using the same exePath and
+// OcrArgs, and returns a pool ready to serve requests.
+//
+// It is the caller's responsibility to Close the pool when finished.
+func NewPpocrPool(exePath string, args OcrArgs, size int) (*PpocrPool, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("paddleocr: pool size must be positive, got %d", size)
+	}
+	probeChans := make([]chan probeJob, size)
+	for i := range probeChans {
+		probeChans[i] = make(chan probeJob)
+	}
+	pool := &PpocrPool{
+		exePath:    exePath,
+		args:       args,
+		jobChan:    make(chan poolJob),
+		probeChans: probeChans,
+		closeChan:  make(chan struct{}),
+		requests:   make([]int64, size),
+	}
+	workers := make([]*Ppocr, 0, size)
+	for i := 0; i < size; i++ {
+		p, err := NewPpocr(exePath, args)
+		if err != nil {
+			for _, w := range workers {
+				_ = w.Close()
+			}
+			return nil, err
+		}
+		workers = append(workers, p)
+	}
+	pool.workers = workers
+	for i, p := range workers {
+		pool.wg.Add(1)
+		go pool.runWorker(i, p)
+	}
+	return pool, nil
+}
+
+func (pool *PpocrPool) runWorker(id int, p *Ppocr) {
+	defer pool.wg.Done()
+	probeChan := pool.probeChans[id]
+	for {
+		select {
+		case <-pool.closeChan:
+			_ = p.Close()
+			return
+		case job := <-pool.jobChan:
+			data, err := pool.serve(id, p, job)
+			job.resultChan <- poolResult{data, err}
+		case pj := <-probeChan:
+			_, err := pool.serve(id, p, poolJob{ctx: pj.ctx, image: pj.image, useBytes: true})
+			pj.resultChan <- err
+		}
+	}
+}
+
+// serve runs one job against worker p, restarting it first if a previous
+// job left it in a failed state. This reuses the same restart mechanism as
+// Ppocr.restartTimer and a ctx timeout, so a worker that died or hit the
+// 20-minute memory-leak timer is transparently brought back before serving
+// the next request.
+func (pool *PpocrPool) serve(id int, p *Ppocr, job poolJob) ([]byte, error) {
+	p.ppLock.Lock()
+	if p.internalErr != nil {
+		p.restart()
+	}
+	p.ppLock.Unlock()
+	if p.internalErr != nil {
+		return nil, p.internalErr
+	}
+
+	atomic.AddInt64(&pool.requests[id], 1)
+	if job.useBytes {
+		return p.OcrContext(job.ctx, job.image)
+	}
+	return p.OcrFileContext(job.ctx, job.imagePath)
+}
+
+func (pool *PpocrPool) dispatch(job poolJob) ([]byte, error) {
+	atomic.AddInt64(&pool.pending, 1)
+	select {
+	case pool.jobChan <- job:
+		atomic.AddInt64(&pool.pending, -1)
+	case <-pool.closeChan:
+		atomic.AddInt64(&pool.pending, -1)
+		return nil, fmt.Errorf("paddleocr: pool is closed")
+	case <-job.ctx.Done():
+		atomic.AddInt64(&pool.pending, -1)
+		return nil, job.ctx.Err()
+	}
+
+	select {
+	case res := <-job.resultChan:
+		return res.data, res.err
+	case <-job.ctx.Done():
+		return nil, job.ctx.Err()
+	}
+}
+
+// Ocr dispatches image to the least-busy worker in the pool and returns the
+// raw OCR result.
+func (pool *PpocrPool) Ocr(image []byte) ([]byte, error) {
+	return pool.OcrContext(context.Background(), image)
+}
+
+// OcrContext is like Ocr but accepts a context.Context for cancellation and deadlines.
+func (pool *PpocrPool) OcrContext(ctx context.Context, image []byte) ([]byte, error) {
+	return pool.dispatch(poolJob{
+		ctx:        ctx,
+		image:      image,
+		useBytes:   true,
+		resultChan: make(chan poolResult, 1),
+	})
+}
+
+// OcrFile dispatches imagePath to the least-busy worker in the pool and
+// returns the raw OCR result.
+func (pool *PpocrPool) OcrFile(imagePath string) ([]byte, error) {
+	return pool.OcrFileContext(context.Background(), imagePath)
+}
+
+// OcrFileContext is like OcrFile but accepts a context.Context for cancellation and deadlines.
+func (pool *PpocrPool) OcrFileContext(ctx context.Context, imagePath string) ([]byte, error) {
+	return pool.dispatch(poolJob{
+		ctx:        ctx,
+		imagePath:  imagePath,
+		resultChan: make(chan poolResult, 1),
+	})
+}
+
+// OcrClipboard dispatches the image currently in the clipboard to the
+// least-busy worker in the pool.
+func (pool *PpocrPool) OcrClipboard() ([]byte, error) {
+	return pool.OcrFile(clipboardImagePath)
+}
+
+// OcrAndParse is like Ocr but parses the result.
+func (pool *PpocrPool) OcrAndParse(image []byte) (Result, error) {
+	b, err := pool.Ocr(image)
+	if err != nil {
+		return Result{}, err
+	}
+	return ParseResult(b)
+}
+
+// OcrFileAndParse is like OcrFile but parses the result.
+func (pool *PpocrPool) OcrFileAndParse(imagePath string) (Result, error) {
+	b, err := pool.OcrFile(imagePath)
+	if err != nil {
+		return Result{}, err
+	}
+	return ParseResult(b)
+}
+
+// OcrClipboardAndParse is like OcrClipboard but parses the result.
+func (pool *PpocrPool) OcrClipboardAndParse() (Result, error) {
+	return pool.OcrFileAndParse(clipboardImagePath)
+}
+
+// HealthCheck round-trips image through every worker in the pool
+// concurrently, one probe per worker sent directly via probeChans instead of
+// the shared jobChan, so a single wedged worker is caught even though the
+// pool's normal dispatch would always hand traffic to its least-busy peers.
+// It returns one error per worker, indexed the same as PoolMetrics, with a
+// nil entry for a worker that handled its probe successfully.
+func (pool *PpocrPool) HealthCheck(ctx context.Context, image []byte) []error {
+	errs := make([]error, len(pool.probeChans))
+	var wg sync.WaitGroup
+	for i, probeChan := range pool.probeChans {
+		wg.Add(1)
+		go func(i int, probeChan chan probeJob) {
+			defer wg.Done()
+			resultChan := make(chan error, 1)
+			select {
+			case probeChan <- probeJob{ctx: ctx, image: image, resultChan: resultChan}:
+			case <-pool.closeChan:
+				errs[i] = fmt.Errorf("paddleocr: pool is closed")
+				return
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			select {
+			case errs[i] = <-resultChan:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+			}
+		}(i, probeChan)
+	}
+	wg.Wait()
+	return errs
+}
+
+// Metrics returns a snapshot of the pool's current queue depth and
+// per-worker restart/request counts. WorkerRestarts reflects every restart
+// of each worker's Ppocr, including ones triggered by a per-call ReadTimeout
+// or ctx cancellation, not just ones serve itself triggered.
+func (pool *PpocrPool) Metrics() PoolMetrics {
+	m := PoolMetrics{
+		QueueDepth:     atomic.LoadInt64(&pool.pending),
+		WorkerRestarts: make([]int64, len(pool.workers)),
+		WorkerRequests: make([]int64, len(pool.requests)),
+	}
+	for i, p := range pool.workers {
+		m.WorkerRestarts[i] = p.RestartCount()
+	}
+	for i := range pool.requests {
+		m.WorkerRequests[i] = atomic.LoadInt64(&pool.requests[i])
+	}
+	return m
+}
+
+// Close signals all workers to stop, waiting for any in-flight request to
+// finish before terminating the child processes.
+//
+// Warning: This method should only be called once.
+func (pool *PpocrPool) Close() error {
+	pool.closeOnce.Do(func() {
+		close(pool.closeChan)
+	})
+	pool.wg.Wait()
+	return nil
+}
@@ -0,0 +1,597 @@
+package paddleocr
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+)
+
+// Preprocessor transforms an image before it is sent to PaddleOCR-json.
+// Implementations should be deterministic and free of side effects.
+type Preprocessor interface {
+	Process(img image.Image) (image.Image, error)
+}
+
+// PreprocessOption configures the preprocessing pipeline run by OcrImage.
+type PreprocessOption func(*preprocessConfig)
+
+type binarizeMethod int
+
+const (
+	binarizeNone binarizeMethod = iota
+	binarizeOtsuMethod
+	binarizeSauvolaMethod
+)
+
+type preprocessConfig struct {
+	grayscale bool
+
+	binarize      binarizeMethod
+	sauvolaWindow int
+	sauvolaK      float64
+
+	deskew bool
+
+	downscale    bool
+	limitSideLen int32
+
+	unsharp       bool
+	unsharpAmount float64
+
+	multi           bool
+	multiThresholds []uint8
+}
+
+func defaultPreprocessConfig() *preprocessConfig {
+	return &preprocessConfig{
+		sauvolaWindow: 25,
+		sauvolaK:      0.2,
+		limitSideLen:  960,
+		unsharpAmount: 0.5,
+	}
+}
+
+// WithGrayscale converts the image to grayscale.
+func WithGrayscale() PreprocessOption {
+	return func(c *preprocessConfig) { c.grayscale = true }
+}
+
+// WithBinarizeOtsu binarizes the grayscale image using a single global
+// threshold chosen by Otsu's method.
+func WithBinarizeOtsu() PreprocessOption {
+	return func(c *preprocessConfig) {
+		c.grayscale = true
+		c.binarize = binarizeOtsuMethod
+	}
+}
+
+// WithBinarizeSauvola binarizes the grayscale image using the local Sauvola
+// threshold, which copes better than Otsu with uneven lighting and shadows.
+// window is the side length in pixels of the local neighbourhood (e.g. 25)
+// and k is the Sauvola sensitivity constant (typically 0.2-0.5). Values <= 0
+// keep the current default.
+func WithBinarizeSauvola(window int, k float64) PreprocessOption {
+	return func(c *preprocessConfig) {
+		c.grayscale = true
+		c.binarize = binarizeSauvolaMethod
+		if window > 0 {
+			c.sauvolaWindow = window
+		}
+		if k > 0 {
+			c.sauvolaK = k
+		}
+	}
+}
+
+// WithDeskew estimates the page skew angle from the dominant near-horizontal
+// edges via a Hough-line vote and rotates the image to correct it.
+func WithDeskew() PreprocessOption {
+	return func(c *preprocessConfig) { c.deskew = true }
+}
+
+// WithDownscale resizes the image so its long side matches limitSideLen,
+// mirroring OcrArgs.LimitSideLen. Images whose long side is already at or
+// below limitSideLen are left untouched. limitSideLen <= 0 keeps the current
+// default.
+func WithDownscale(limitSideLen int32) PreprocessOption {
+	return func(c *preprocessConfig) {
+		c.downscale = true
+		if limitSideLen > 0 {
+			c.limitSideLen = limitSideLen
+		}
+	}
+}
+
+// WithUnsharpMask sharpens the image by the given amount (0 disables
+// sharpening, 1 is a typical strength) after every other stage has run.
+func WithUnsharpMask(amount float64) PreprocessOption {
+	return func(c *preprocessConfig) {
+		c.unsharp = true
+		c.unsharpAmount = amount
+	}
+}
+
+// WithMultiBinarize makes OcrImage run the pipeline once per threshold in
+// thresholds (or a sensible built-in set if none is given), OCR each
+// variant, and keep the parsed Result with the highest mean Data.Score. It
+// is more expensive than a single pass but more robust when the best
+// binarization threshold is hard to predict up front.
+func WithMultiBinarize(thresholds ...uint8) PreprocessOption {
+	return func(c *preprocessConfig) {
+		c.grayscale = true
+		c.multi = true
+		if len(thresholds) > 0 {
+			c.multiThresholds = thresholds
+		}
+	}
+}
+
+// PreprocessScan is tuned for flat-bed scans of documents: grayscale, a
+// global Otsu binarization, deskew, and downscale to LimitSideLen.
+func PreprocessScan(c *preprocessConfig) {
+	c.grayscale = true
+	c.binarize = binarizeOtsuMethod
+	c.deskew = true
+	c.downscale = true
+}
+
+// PreprocessPhoto is tuned for phone photos of documents: grayscale, local
+// Sauvola binarization (robust to uneven lighting and shadows), deskew and
+// downscale.
+func PreprocessPhoto(c *preprocessConfig) {
+	c.grayscale = true
+	c.binarize = binarizeSauvolaMethod
+	c.deskew = true
+	c.downscale = true
+}
+
+// PreprocessScreenshot is tuned for screenshots and other already-crisp
+// digital images: no binarization or deskew, just a light unsharp mask and
+// downscale for oversized captures.
+func PreprocessScreenshot(c *preprocessConfig) {
+	c.unsharp = true
+	c.unsharpAmount = 0.3
+	c.downscale = true
+	c.limitSideLen = 2880
+}
+
+// pipeline is the default Preprocessor built from a preprocessConfig. It
+// runs each configured stage in a fixed order: grayscale, binarize,
+// downscale, deskew, unsharp mask.
+type pipeline struct {
+	cfg *preprocessConfig
+}
+
+func newPipeline(opts ...PreprocessOption) *pipeline {
+	cfg := defaultPreprocessConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return &pipeline{cfg: cfg}
+}
+
+// Process runs the configured stages over img, binarizing (if enabled) with
+// Otsu's global threshold or Sauvola's local one.
+func (pl *pipeline) Process(img image.Image) (image.Image, error) {
+	return pl.run(img, 0, false)
+}
+
+func (pl *pipeline) run(img image.Image, threshold uint8, forceThreshold bool) (image.Image, error) {
+	var out image.Image = img
+	var gray *image.Gray
+	if pl.cfg.grayscale {
+		gray = toGray(out)
+		out = gray
+	}
+	if gray != nil {
+		switch {
+		case forceThreshold:
+			gray = binarize(gray, threshold)
+		case pl.cfg.binarize == binarizeOtsuMethod:
+			gray = binarize(gray, otsuThreshold(gray))
+		case pl.cfg.binarize == binarizeSauvolaMethod:
+			gray = binarizeSauvola(gray, pl.cfg.sauvolaWindow, pl.cfg.sauvolaK)
+		}
+		out = gray
+	}
+	if pl.cfg.downscale {
+		out = downscaleToLimit(out, pl.cfg.limitSideLen)
+	}
+	if pl.cfg.deskew {
+		// Skew angle is scale-invariant, so deskew runs after downscale:
+		// the O(angles x pixels) Hough vote in houghDeskewAngle then works
+		// over the (typically much smaller) downscaled image instead of
+		// the full-resolution source, which matters on the multi-megapixel
+		// scans/photos/long images this pipeline targets.
+		out = rotateImage(out, -houghDeskewAngle(toGray(out), 10, 0.5))
+	}
+	if pl.cfg.unsharp {
+		out = unsharpMask(out, pl.cfg.unsharpAmount)
+	}
+	return out, nil
+}
+
+func toGray(img image.Image) *image.Gray {
+	b := img.Bounds()
+	gray := image.NewGray(b)
+	draw.Draw(gray, b, img, b.Min, draw.Src)
+	return gray
+}
+
+// otsuThreshold computes the global threshold that maximizes inter-class
+// variance between foreground and background pixels.
+func otsuThreshold(gray *image.Gray) uint8 {
+	var hist [256]int
+	b := gray.Bounds()
+	total := 0
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			hist[gray.GrayAt(x, y).Y]++
+			total++
+		}
+	}
+	var sum float64
+	for i, h := range hist {
+		sum += float64(i) * float64(h)
+	}
+	var sumB, wB float64
+	var maxVar float64
+	threshold := uint8(0)
+	for t := 0; t < 256; t++ {
+		wB += float64(hist[t])
+		if wB == 0 {
+			continue
+		}
+		wF := float64(total) - wB
+		if wF == 0 {
+			break
+		}
+		sumB += float64(t) * float64(hist[t])
+		mB := sumB / wB
+		mF := (sum - sumB) / wF
+		betweenVar := wB * wF * (mB - mF) * (mB - mF)
+		if betweenVar > maxVar {
+			maxVar = betweenVar
+			threshold = uint8(t)
+		}
+	}
+	return threshold
+}
+
+func binarize(gray *image.Gray, threshold uint8) *image.Gray {
+	b := gray.Bounds()
+	out := image.NewGray(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if gray.GrayAt(x, y).Y >= threshold {
+				out.SetGray(x, y, color.Gray{Y: 255})
+			} else {
+				out.SetGray(x, y, color.Gray{Y: 0})
+			}
+		}
+	}
+	return out
+}
+
+// binarizeSauvola applies the Sauvola local-threshold algorithm using
+// integral images so each pixel's neighbourhood mean/variance are computed
+// in constant time.
+func binarizeSauvola(gray *image.Gray, window int, k float64) *image.Gray {
+	b := gray.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	sum := make([][]float64, h+1)
+	sumSq := make([][]float64, h+1)
+	for i := range sum {
+		sum[i] = make([]float64, w+1)
+		sumSq[i] = make([]float64, w+1)
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := float64(gray.GrayAt(b.Min.X+x, b.Min.Y+y).Y)
+			sum[y+1][x+1] = v + sum[y][x+1] + sum[y+1][x] - sum[y][x]
+			sumSq[y+1][x+1] = v*v + sumSq[y][x+1] + sumSq[y+1][x] - sumSq[y][x]
+		}
+	}
+
+	half := window / 2
+	out := image.NewGray(b)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			x0, x1 := maxInt(0, x-half), minInt(w-1, x+half)
+			y0, y1 := maxInt(0, y-half), minInt(h-1, y+half)
+			n := float64((x1 - x0 + 1) * (y1 - y0 + 1))
+			area := sum[y1+1][x1+1] - sum[y0][x1+1] - sum[y1+1][x0] + sum[y0][x0]
+			areaSq := sumSq[y1+1][x1+1] - sumSq[y0][x1+1] - sumSq[y1+1][x0] + sumSq[y0][x0]
+			mean := area / n
+			variance := areaSq/n - mean*mean
+			if variance < 0 {
+				variance = 0
+			}
+			stddev := math.Sqrt(variance)
+			threshold := mean * (1 + k*(stddev/128-1))
+
+			v := float64(gray.GrayAt(b.Min.X+x, b.Min.Y+y).Y)
+			if v >= threshold {
+				out.SetGray(b.Min.X+x, b.Min.Y+y, color.Gray{Y: 255})
+			} else {
+				out.SetGray(b.Min.X+x, b.Min.Y+y, color.Gray{Y: 0})
+			}
+		}
+	}
+	return out
+}
+
+// houghDeskewAngle estimates the dominant skew angle (in degrees) of
+// near-horizontal text lines using a Hough-transform vote over Sobel edge
+// pixels, searching +/-maxAngle degrees in step-degree increments.
+func houghDeskewAngle(gray *image.Gray, maxAngle, step float64) float64 {
+	edges := sobelEdges(gray)
+	b := edges.Bounds()
+
+	bestAngle := 0.0
+	bestVotes := -1
+	for angle := -maxAngle; angle <= maxAngle; angle += step {
+		theta := angle * math.Pi / 180
+		cos, sin := math.Cos(theta), math.Sin(theta)
+		votes := make(map[int]int)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				if edges.GrayAt(x, y).Y == 0 {
+					continue
+				}
+				rho := int(-float64(x)*sin + float64(y)*cos)
+				votes[rho]++
+			}
+		}
+		for _, v := range votes {
+			if v > bestVotes {
+				bestVotes = v
+				bestAngle = angle
+			}
+		}
+	}
+	return bestAngle
+}
+
+func sobelEdges(gray *image.Gray) *image.Gray {
+	b := gray.Bounds()
+	out := image.NewGray(b)
+	gxKernel := [3][3]int{{-1, 0, 1}, {-2, 0, 2}, {-1, 0, 1}}
+	gyKernel := [3][3]int{{-1, -2, -1}, {0, 0, 0}, {1, 2, 1}}
+	at := func(x, y int) int {
+		x = maxInt(b.Min.X, minInt(b.Max.X-1, x))
+		y = maxInt(b.Min.Y, minInt(b.Max.Y-1, y))
+		return int(gray.GrayAt(x, y).Y)
+	}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			var gx, gy int
+			for j := -1; j <= 1; j++ {
+				for i := -1; i <= 1; i++ {
+					v := at(x+i, y+j)
+					gx += gxKernel[j+1][i+1] * v
+					gy += gyKernel[j+1][i+1] * v
+				}
+			}
+			if mag := math.Hypot(float64(gx), float64(gy)); mag > 128 {
+				out.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+	return out
+}
+
+// rotateImage rotates img by angleDeg degrees about its center using
+// nearest-neighbor sampling, padding uncovered corners with white.
+func rotateImage(img image.Image, angleDeg float64) image.Image {
+	if angleDeg == 0 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	out := image.NewRGBA(image.Rect(0, 0, w, h))
+	cx, cy := float64(w)/2, float64(h)/2
+	theta := -angleDeg * math.Pi / 180
+	cos, sin := math.Cos(theta), math.Sin(theta)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dx, dy := float64(x)-cx, float64(y)-cy
+			srcX := dx*cos - dy*sin + cx
+			srcY := dx*sin + dy*cos + cy
+			sx := b.Min.X + int(math.Round(srcX))
+			sy := b.Min.Y + int(math.Round(srcY))
+			if sx < b.Min.X || sx >= b.Max.X || sy < b.Min.Y || sy >= b.Max.Y {
+				out.Set(x, y, color.White)
+				continue
+			}
+			out.Set(x, y, img.At(sx, sy))
+		}
+	}
+	return out
+}
+
+// downscaleToLimit resizes img so its long side matches limitSideLen,
+// leaving images already within the limit untouched.
+func downscaleToLimit(img image.Image, limitSideLen int32) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	longSide := maxInt(w, h)
+	if limitSideLen <= 0 || longSide <= int(limitSideLen) {
+		return img
+	}
+	scale := float64(limitSideLen) / float64(longSide)
+	newW := maxInt(1, int(float64(w)*scale))
+	newH := maxInt(1, int(float64(h)*scale))
+	return resizeNearest(img, newW, newH)
+}
+
+func resizeNearest(img image.Image, newW, newH int) image.Image {
+	b := img.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, newW, newH))
+	for y := 0; y < newH; y++ {
+		srcY := b.Min.Y + y*b.Dy()/newH
+		for x := 0; x < newW; x++ {
+			srcX := b.Min.X + x*b.Dx()/newW
+			out.Set(x, y, img.At(srcX, srcY))
+		}
+	}
+	return out
+}
+
+// unsharpMask sharpens img by adding amount times the high-frequency
+// residual (img minus a blurred copy) back onto img.
+func unsharpMask(img image.Image, amount float64) image.Image {
+	b := img.Bounds()
+	blurred := boxBlur(img, 1)
+	out := image.NewGray(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			orig := float64(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+			blur := float64(color.GrayModel.Convert(blurred.At(x, y)).(color.Gray).Y)
+			out.SetGray(x, y, color.Gray{Y: clampByte(orig + amount*(orig-blur))})
+		}
+	}
+	return out
+}
+
+func boxBlur(img image.Image, radius int) image.Image {
+	b := img.Bounds()
+	out := image.NewGray(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			var sum, count int
+			for dy := -radius; dy <= radius; dy++ {
+				for dx := -radius; dx <= radius; dx++ {
+					nx, ny := x+dx, y+dy
+					if nx < b.Min.X || nx >= b.Max.X || ny < b.Min.Y || ny >= b.Max.Y {
+						continue
+					}
+					sum += int(color.GrayModel.Convert(img.At(nx, ny)).(color.Gray).Y)
+					count++
+				}
+			}
+			out.SetGray(x, y, color.Gray{Y: uint8(sum / count)})
+		}
+	}
+	return out
+}
+
+func clampByte(v float64) uint8 {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint8(v)
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// OcrImage runs a configurable preprocessing pipeline (grayscale,
+// binarization, deskew, downscale, unsharp mask) over img, encodes the
+// result to PNG in memory, and feeds it through the existing base64 path.
+//
+// Use the PreprocessScan, PreprocessPhoto, or PreprocessScreenshot presets
+// for common source types, or combine individual With* options. Passing
+// WithMultiBinarize runs the pipeline once per binarization threshold and
+// returns the parsed result with the highest mean Data.Score.
+func (p *Ppocr) OcrImage(img image.Image, opts ...PreprocessOption) (Result, error) {
+	return p.OcrImageContext(context.Background(), img, opts...)
+}
+
+// OcrImageContext is like OcrImage but accepts a context.Context for
+// cancellation and deadlines.
+func (p *Ppocr) OcrImageContext(ctx context.Context, img image.Image, opts ...PreprocessOption) (Result, error) {
+	// Downscaling defaults to this Ppocr's OcrArgs.LimitSideLen when it is set.
+	if l := p.args.LimitSideLen; l != nil && *l > 0 {
+		opts = append([]PreprocessOption{func(c *preprocessConfig) { c.limitSideLen = *l }}, opts...)
+	}
+	pl := newPipeline(opts...)
+	if pl.cfg.multi {
+		return p.ocrImageMulti(ctx, img, pl)
+	}
+	processed, err := pl.Process(img)
+	if err != nil {
+		return Result{}, err
+	}
+	data, err := encodePNG(processed)
+	if err != nil {
+		return Result{}, err
+	}
+	return p.OcrAndParseContext(ctx, data)
+}
+
+func (p *Ppocr) ocrImageMulti(ctx context.Context, img image.Image, pl *pipeline) (Result, error) {
+	thresholds := pl.cfg.multiThresholds
+	if len(thresholds) == 0 {
+		thresholds = []uint8{100, 128, 150, 180}
+	}
+
+	var best Result
+	var bestScore float32 = -1
+	bestErr := fmt.Errorf("paddleocr: no binarization threshold produced a result")
+	for _, t := range thresholds {
+		processed, err := pl.run(img, t, true)
+		if err != nil {
+			bestErr = err
+			continue
+		}
+		data, err := encodePNG(processed)
+		if err != nil {
+			bestErr = err
+			continue
+		}
+		result, err := p.OcrAndParseContext(ctx, data)
+		if err != nil {
+			bestErr = err
+			continue
+		}
+		if score := meanScore(result); score > bestScore {
+			bestScore = score
+			best = result
+			bestErr = nil
+		}
+	}
+	if bestScore < 0 {
+		return Result{}, bestErr
+	}
+	return best, nil
+}
+
+func meanScore(r Result) float32 {
+	if len(r.Data) == 0 {
+		return 0
+	}
+	var sum float32
+	for _, d := range r.Data {
+		sum += d.Score
+	}
+	return sum / float32(len(r.Data))
+}
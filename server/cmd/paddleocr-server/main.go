@@ -0,0 +1,59 @@
+// Command paddleocr-server runs the HTTP+JSON front-end for a pool of
+// PaddleOCR-json worker processes.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/doraemonkeys/paddleocr/server"
+)
+
+func main() {
+	exePath := flag.String("exe", "", "path to the PaddleOCR-json executable (required)")
+	addr := flag.String("addr", ":8080", "HTTP listen address")
+	workers := flag.Int("workers", 1, "number of pooled PaddleOCR-json worker processes")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 30*time.Second, "max time to wait for in-flight OCR jobs to drain on shutdown")
+	flag.Parse()
+
+	if *exePath == "" {
+		log.Fatal("-exe is required")
+	}
+
+	srv, err := server.New(server.Config{
+		ExePath:         *exePath,
+		Workers:         *workers,
+		ShutdownTimeout: *shutdownTimeout,
+	})
+	if err != nil {
+		log.Fatalf("starting paddleocr server: %v", err)
+	}
+
+	httpSrv := &http.Server{Addr: *addr, Handler: srv}
+	go func() {
+		if err := httpSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("paddleocr server: %v", err)
+		}
+	}()
+	log.Printf("paddleocr server listening on %s with %d workers", *addr, *workers)
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	<-sigChan
+
+	log.Println("shutting down, draining in-flight OCR jobs...")
+	ctx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	if err := httpSrv.Shutdown(ctx); err != nil {
+		log.Printf("http shutdown: %v", err)
+	}
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("pool shutdown: %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/doraemonkeys/paddleocr"
+)
+
+// metrics accumulates /ocr request counts, a latency histogram, and
+// per-Result.Code error counts for exposition in Prometheus text format.
+type metrics struct {
+	mu sync.Mutex
+
+	requestsTotal uint64
+	errorsByCode  map[int]uint64
+
+	// latencyBuckets holds the histogram's upper bounds in seconds; the
+	// final slot in latencyCounts is the implicit +Inf bucket.
+	latencyBuckets []float64
+	latencyCounts  []uint64
+	latencySum     float64
+	latencyCount   uint64
+}
+
+func newMetrics() *metrics {
+	buckets := []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	return &metrics{
+		errorsByCode:   make(map[int]uint64),
+		latencyBuckets: buckets,
+		latencyCounts:  make([]uint64, len(buckets)+1),
+	}
+}
+
+func (m *metrics) observe(d time.Duration, code int, isError bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requestsTotal++
+	secs := d.Seconds()
+	m.latencySum += secs
+	m.latencyCount++
+	idx := len(m.latencyBuckets)
+	for i, b := range m.latencyBuckets {
+		if secs <= b {
+			idx = i
+			break
+		}
+	}
+	m.latencyCounts[idx]++
+
+	if isError {
+		m.errorsByCode[code]++
+	}
+}
+
+// writePrometheus renders request/latency metrics plus the pool's per-worker
+// restart and request counts in the Prometheus text exposition format.
+func (m *metrics) writePrometheus(w io.Writer, pool paddleocr.PoolMetrics) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP paddleocr_requests_total Total number of /ocr requests handled.")
+	fmt.Fprintln(w, "# TYPE paddleocr_requests_total counter")
+	fmt.Fprintf(w, "paddleocr_requests_total %d\n", m.requestsTotal)
+
+	fmt.Fprintln(w, "# HELP paddleocr_errors_total Total number of /ocr requests that returned a non-success Result.Code, by code; code 0 counts requests that failed without a Result.")
+	fmt.Fprintln(w, "# TYPE paddleocr_errors_total counter")
+	codes := make([]int, 0, len(m.errorsByCode))
+	for code := range m.errorsByCode {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		fmt.Fprintf(w, "paddleocr_errors_total{code=\"%d\"} %d\n", code, m.errorsByCode[code])
+	}
+
+	fmt.Fprintln(w, "# HELP paddleocr_request_duration_seconds Histogram of /ocr request latency.")
+	fmt.Fprintln(w, "# TYPE paddleocr_request_duration_seconds histogram")
+	var cumulative uint64
+	for i, b := range m.latencyBuckets {
+		cumulative += m.latencyCounts[i]
+		fmt.Fprintf(w, "paddleocr_request_duration_seconds_bucket{le=\"%g\"} %d\n", b, cumulative)
+	}
+	cumulative += m.latencyCounts[len(m.latencyBuckets)]
+	fmt.Fprintf(w, "paddleocr_request_duration_seconds_bucket{le=\"+Inf\"} %d\n", cumulative)
+	fmt.Fprintf(w, "paddleocr_request_duration_seconds_sum %g\n", m.latencySum)
+	fmt.Fprintf(w, "paddleocr_request_duration_seconds_count %d\n", m.latencyCount)
+
+	fmt.Fprintln(w, "# HELP paddleocr_worker_restarts_total Number of times each pool worker has been restarted.")
+	fmt.Fprintln(w, "# TYPE paddleocr_worker_restarts_total counter")
+	for i, n := range pool.WorkerRestarts {
+		fmt.Fprintf(w, "paddleocr_worker_restarts_total{worker=\"%d\"} %d\n", i, n)
+	}
+
+	fmt.Fprintln(w, "# HELP paddleocr_worker_requests_total Number of requests each pool worker has served.")
+	fmt.Fprintln(w, "# TYPE paddleocr_worker_requests_total counter")
+	for i, n := range pool.WorkerRequests {
+		fmt.Fprintf(w, "paddleocr_worker_requests_total{worker=\"%d\"} %d\n", i, n)
+	}
+
+	fmt.Fprintln(w, "# HELP paddleocr_queue_depth Number of requests currently waiting for a free worker.")
+	fmt.Fprintln(w, "# TYPE paddleocr_queue_depth gauge")
+	fmt.Fprintf(w, "paddleocr_queue_depth %d\n", pool.QueueDepth)
+}
@@ -0,0 +1,209 @@
+// Package server exposes a paddleocr.PpocrPool over HTTP+JSON, mirroring
+// the RPC-worker deployment model used by open-ocr so this wrapper can be
+// integrated into non-Go services.
+//
+// A gRPC front-end was considered but is left out of this package: it would
+// require generated stubs from a .proto definition and the
+// google.golang.org/grpc module, neither of which is vendored in this
+// repository. The HTTP+JSON server below covers the same request/response
+// shape and can be fronted by a gRPC-to-HTTP gateway if needed.
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/doraemonkeys/paddleocr"
+)
+
+// defaultHealthImage is a minimal 1x1 PNG used by /healthz when
+// Config.HealthImage is unset, so a default server always performs a real
+// OCR round-trip through every worker instead of only reporting "up".
+var defaultHealthImage = func() []byte {
+	img := image.NewGray(image.Rect(0, 0, 1, 1))
+	img.Set(0, 0, color.Gray{Y: 255})
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img)
+	return buf.Bytes()
+}()
+
+// Config holds the settings needed to start a Server.
+type Config struct {
+	// ExePath is the path to the PaddleOCR-json executable.
+	ExePath string
+	// Args are the OCR arguments shared by every pooled worker.
+	Args paddleocr.OcrArgs
+	// Workers is the number of pooled Ppocr processes (backs the -workers flag).
+	Workers int
+	// HealthImage is round-tripped through every worker in the pool by
+	// /healthz to confirm each one is actually producing OCR results, not
+	// just that its process is alive. Defaults to a built-in 1x1 PNG
+	// (defaultHealthImage) if unset.
+	HealthImage []byte
+	// ShutdownTimeout is carried in the Config but not read by Server:
+	// Shutdown waits until the ctx passed to it is done, and /healthz uses a
+	// fixed 10-second timeout.
+	ShutdownTimeout time.Duration
+}
+
+// Server exposes a PpocrPool over HTTP+JSON.
+type Server struct {
+	cfg     Config
+	pool    *paddleocr.PpocrPool
+	metrics *metrics
+	mux     *http.ServeMux
+}
+
+// New creates a Server backed by a freshly started PpocrPool of cfg.Workers
+// workers.
+//
+// It is the caller's responsibility to Shutdown the server when finished.
+func New(cfg Config) (*Server, error) {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if len(cfg.HealthImage) == 0 {
+		cfg.HealthImage = defaultHealthImage
+	}
+	pool, err := paddleocr.NewPpocrPool(cfg.ExePath, cfg.Args, cfg.Workers)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		cfg:     cfg,
+		pool:    pool,
+		metrics: newMetrics(),
+		mux:     http.NewServeMux(),
+	}
+	s.mux.HandleFunc("/ocr", s.handleOcr)
+	s.mux.HandleFunc("/healthz", s.handleHealthz)
+	s.mux.HandleFunc("/metrics", s.handleMetrics)
+	return s, nil
+}
+
+// ServeHTTP implements http.Handler, so Server can be mounted on any
+// net/http-compatible listener or test harness.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Shutdown drains in-flight OCR jobs and stops the underlying worker pool.
+func (s *Server) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		_ = s.pool.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type ocrRequest struct {
+	ImagePath   string `json:"image_path,omitempty"`
+	ImageBase64 string `json:"image_base64,omitempty"`
+}
+
+type ocrResponse struct {
+	Code int              `json:"code"`
+	Msg  string           `json:"msg"`
+	Data []paddleocr.Data `json:"data,omitempty"`
+}
+
+// handleOcr serves POST /ocr, accepting either a JSON body with
+// image_path/image_base64 or a multipart/form-data upload with an "image"
+// file field, and returns the parsed paddleocr.Result as JSON.
+func (s *Server) handleOcr(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	start := time.Now()
+	result, err := s.runOcr(r)
+	if err != nil {
+		s.metrics.observe(time.Since(start), 0, true)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.metrics.observe(time.Since(start), result.Code, result.Code != paddleocr.CodeSuccess)
+	writeJSON(w, http.StatusOK, ocrResponse{Code: result.Code, Msg: result.Msg, Data: result.Data})
+}
+
+func (s *Server) runOcr(r *http.Request) (paddleocr.Result, error) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("image")
+		if err != nil {
+			return paddleocr.Result{}, fmt.Errorf("reading multipart image: %w", err)
+		}
+		defer file.Close()
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return paddleocr.Result{}, err
+		}
+		return s.pool.OcrAndParse(data)
+	}
+
+	var req ocrRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return paddleocr.Result{}, fmt.Errorf("decoding request body: %w", err)
+	}
+	switch {
+	case req.ImagePath != "":
+		return s.pool.OcrFileAndParse(req.ImagePath)
+	case req.ImageBase64 != "":
+		data, err := base64.StdEncoding.DecodeString(req.ImageBase64)
+		if err != nil {
+			return paddleocr.Result{}, fmt.Errorf("decoding image_base64: %w", err)
+		}
+		return s.pool.OcrAndParse(data)
+	default:
+		return paddleocr.Result{}, fmt.Errorf("one of image_path, image_base64, or a multipart image field is required")
+	}
+}
+
+// handleHealthz serves GET /healthz. It round-trips Config.HealthImage
+// through every worker in the pool (not just the least-busy one a plain
+// OcrContext call would reach), so a worker that is up but wedged is
+// reported as unhealthy rather than just "process alive".
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	errs := s.pool.HealthCheck(ctx, s.cfg.HealthImage)
+	var unhealthy []string
+	for i, err := range errs {
+		if err != nil {
+			unhealthy = append(unhealthy, fmt.Sprintf("worker %d: %v", i, err))
+		}
+	}
+	if len(unhealthy) > 0 {
+		http.Error(w, fmt.Sprintf("unhealthy: %s", strings.Join(unhealthy, "; ")), http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("ok"))
+}
+
+// handleMetrics serves GET /metrics in the Prometheus text exposition format.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	s.metrics.writePrometheus(w, s.pool.Metrics())
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}